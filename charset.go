@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// metaCharsetRe is a fallback scan for a declared charset (e.g.
+// <meta charset="..."> or <meta http-equiv="Content-Type" content="...;
+// charset=...">) when the primary detection pass fails to decode cleanly.
+var metaCharsetRe = regexp.MustCompile(`(?i)charset\s*=\s*["']?([\w-]+)`)
+
+// decodeBody detects the charset of an HTTP response body and transcodes it
+// to UTF-8. Detection combines BOM sniffing, the Content-Type charset
+// parameter, and a scan of the first ~1024 bytes for <meta charset> /
+// <meta http-equiv="Content-Type"> declarations. If that decode fails or
+// produces invalid UTF-8, it retries using the label from a raw meta-tag
+// scan, then finally falls back to treating the body as UTF-8 as-is.
+func decodeBody(body []byte, contentType string) ([]byte, string, error) {
+	enc, name, _ := charset.DetermineEncoding(body, contentType)
+
+	if decoded, err := decodeWith(body, enc); err == nil && utf8.Valid(decoded) {
+		return decoded, name, nil
+	}
+
+	sniffLen := 1024
+	if sniffLen > len(body) {
+		sniffLen = len(body)
+	}
+	if m := metaCharsetRe.FindSubmatch(body[:sniffLen]); m != nil {
+		label := string(m[1])
+		if fallbackEnc, err := htmlindex.Get(label); err == nil {
+			if decoded, err := decodeWith(body, fallbackEnc); err == nil && utf8.Valid(decoded) {
+				return decoded, label, nil
+			}
+		}
+	}
+
+	return body, "utf-8", nil
+}
+
+func decodeWith(body []byte, enc encoding.Encoding) ([]byte, error) {
+	return io.ReadAll(transform.NewReader(bytes.NewReader(body), enc.NewDecoder()))
+}
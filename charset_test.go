@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func mustEncode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	out, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("failed to encode fixture: %v", err)
+	}
+	return out
+}
+
+func TestDecodeBodyShiftJIS(t *testing.T) {
+	const want = "こんにちは"
+	page := `<html><head><meta charset="shift_jis"><title>` + want + `</title></head><body></body></html>`
+	body := mustEncode(t, japanese.ShiftJIS, page)
+
+	decoded, detected, err := decodeBody(body, "text/html")
+	if err != nil {
+		t.Fatalf("decodeBody returned error: %v", err)
+	}
+	if !strings.Contains(string(decoded), want) {
+		t.Errorf("decoded body does not contain %q: %s", want, decoded)
+	}
+	if detected == "" {
+		t.Errorf("expected a detected charset name, got empty string")
+	}
+}
+
+func TestDecodeBodyWindows1251(t *testing.T) {
+	const want = "Привет, мир"
+	page := `<html><head><meta charset="windows-1251"><title>` + want + `</title></head><body></body></html>`
+	body := mustEncode(t, charmap.Windows1251, page)
+
+	decoded, _, err := decodeBody(body, "text/html")
+	if err != nil {
+		t.Fatalf("decodeBody returned error: %v", err)
+	}
+	if !strings.Contains(string(decoded), want) {
+		t.Errorf("decoded body does not contain %q: %s", want, decoded)
+	}
+}
+
+func TestDecodeBodyPlainUTF8(t *testing.T) {
+	page := `<html><head><title>hello</title></head><body></body></html>`
+
+	decoded, _, err := decodeBody([]byte(page), "text/html; charset=utf-8")
+	if err != nil {
+		t.Fatalf("decodeBody returned error: %v", err)
+	}
+	if string(decoded) != page {
+		t.Errorf("expected UTF-8 body to pass through unchanged, got %q", decoded)
+	}
+}
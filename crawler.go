@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CrawlRequest describes the parameters accepted by the /crawl endpoint.
+type CrawlRequest struct {
+	URL            string
+	MaxDepth       int
+	MaxPages       int
+	AllowedDomains []string
+	SameHostOnly   bool
+	Concurrency    int
+}
+
+// CrawlResult is a single entry in the NDJSON stream produced by /crawl. A
+// page that failed to scrape still produces a result, carrying the
+// existing ErrorResponse shape plus the URL that failed, so one bad page
+// never aborts the rest of the crawl.
+type CrawlResult struct {
+	URL         string         `json:"url"`
+	ScrapedData *ScrapedData   `json:"data,omitempty"`
+	Error       *ErrorResponse `json:"error,omitempty"`
+}
+
+type crawlJob struct {
+	url   string
+	depth int
+}
+
+// Crawler performs a concurrency-bounded crawl starting from a seed URL,
+// honoring a depth limit, a page budget, a domain allow-list, and each
+// host's robots.txt.
+type Crawler struct {
+	req     CrawlRequest
+	robots  *robotsCache
+	visited map[string]bool
+	mu      sync.Mutex
+}
+
+// NewCrawler builds a Crawler for req, filling in sane defaults for any
+// unset limits.
+func NewCrawler(req CrawlRequest) *Crawler {
+	if req.Concurrency <= 0 {
+		req.Concurrency = 4
+	}
+	if req.MaxDepth <= 0 {
+		req.MaxDepth = 1
+	}
+	if req.MaxPages <= 0 {
+		req.MaxPages = 50
+	}
+	return &Crawler{
+		req:     req,
+		robots:  newRobotsCache(),
+		visited: make(map[string]bool),
+	}
+}
+
+// Run starts the crawl and returns a channel of results; it is closed once
+// the frontier is exhausted or the page budget is reached.
+func (c *Crawler) Run(ctx context.Context) <-chan CrawlResult {
+	out := make(chan CrawlResult)
+	jobs := make(chan crawlJob, c.req.Concurrency*4)
+	done := make(chan struct{})
+	var wg sync.WaitGroup // jobs queued or in flight
+
+	var enqueue func(crawlJob)
+	enqueue = func(j crawlJob) {
+		norm := normalizeURL(j.url)
+
+		c.mu.Lock()
+		if c.visited[norm] || len(c.visited) >= c.req.MaxPages {
+			c.mu.Unlock()
+			return
+		}
+		c.visited[norm] = true
+		c.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			select {
+			case jobs <- j:
+			case <-done:
+				wg.Done()
+			}
+		}()
+	}
+
+	for i := 0; i < c.req.Concurrency; i++ {
+		go func() {
+			for {
+				select {
+				case j := <-jobs:
+					c.process(ctx, j, out, enqueue)
+					wg.Done()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	enqueue(crawlJob{url: c.req.URL, depth: 0})
+
+	go func() {
+		wg.Wait()
+		close(done)
+		close(out)
+	}()
+
+	return out
+}
+
+func (c *Crawler) process(ctx context.Context, j crawlJob, out chan<- CrawlResult, enqueue func(crawlJob)) {
+	host := hostOf(j.url)
+	scheme := schemeOf(j.url)
+	if !c.robots.allowed(host, scheme, j.url, defaultUserAgent) {
+		return
+	}
+
+	if delay := c.robots.crawlDelay(host, scheme, defaultUserAgent); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	data, _, err := scrapeWebPage(j.url, "")
+	if err != nil {
+		select {
+		case out <- CrawlResult{URL: j.url, Error: &ErrorResponse{Error: "scrape_failed", Message: err.Error()}}:
+		case <-ctx.Done():
+		}
+		return
+	}
+
+	select {
+	case out <- CrawlResult{URL: j.url, ScrapedData: data}:
+	case <-ctx.Done():
+		return
+	}
+
+	if j.depth >= c.req.MaxDepth {
+		return
+	}
+	for _, link := range data.Links {
+		if c.allowedDomain(link.Href) {
+			enqueue(crawlJob{url: link.Href, depth: j.depth + 1})
+		}
+	}
+}
+
+// allowedDomain reports whether rawURL may be added to the frontier given
+// the crawl's same-host and allow-list restrictions.
+func (c *Crawler) allowedDomain(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+
+	if c.req.SameHostOnly && !strings.EqualFold(u.Host, hostOf(c.req.URL)) {
+		return false
+	}
+
+	if len(c.req.AllowedDomains) == 0 {
+		return true
+	}
+	for _, d := range c.req.AllowedDomains {
+		d = strings.TrimSpace(d)
+		if strings.EqualFold(u.Host, d) || strings.HasSuffix(strings.ToLower(u.Host), "."+strings.ToLower(d)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func schemeOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return "https"
+	}
+	return u.Scheme
+}
+
+// normalizeURL canonicalizes rawURL for the visited set: the host is
+// lower-cased, the fragment is dropped, and an empty path becomes "/".
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+func crawlHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	targetURL := r.URL.Query().Get("url")
+	if targetURL == "" {
+		sendError(w, http.StatusBadRequest, "missing_url", "URL parameter is required")
+		return
+	}
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		sendError(w, http.StatusBadRequest, "invalid_url", "URL must be a valid http or https URL")
+		return
+	}
+
+	req := CrawlRequest{
+		URL:          targetURL,
+		MaxDepth:     intParam(r, "max_depth", 1),
+		MaxPages:     intParam(r, "max_pages", 50),
+		SameHostOnly: boolParam(r, "same_host_only", true),
+		Concurrency:  intParam(r, "concurrency", 4),
+	}
+	if domains := r.URL.Query().Get("allowed_domains"); domains != "" {
+		req.AllowedDomains = strings.Split(domains, ",")
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	crawler := NewCrawler(req)
+	encoder := json.NewEncoder(w)
+	for result := range crawler.Run(r.Context()) {
+		if err := encoder.Encode(result); err != nil {
+			log.Printf("Error streaming crawl result for %s: %v", targetURL, err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func intParam(r *http.Request, name string, def int) int {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func boolParam(r *http.Request, name string, def bool) bool {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FetchResult is the outcome of a successful Fetch: the response body and
+// the headers callers need to interpret it.
+type FetchResult struct {
+	Body        []byte
+	ContentType string
+	FromCache   bool
+}
+
+// Fetcher sits between the handlers and the network. It enforces a
+// per-host rate limit with a token bucket, retries 429/5xx and network
+// errors with exponential backoff (honoring Retry-After), and caches
+// successful responses on disk.
+type Fetcher struct {
+	client     *http.Client
+	rate       float64
+	burst      int
+	maxRetries int
+	cacheDir   string
+
+	mu       sync.Mutex
+	limiters map[string]*tokenBucket
+}
+
+// NewFetcher builds a Fetcher. cacheDir disables on-disk caching when empty.
+func NewFetcher(rate float64, burst, maxRetries int, cacheDir string) *Fetcher {
+	return &Fetcher{
+		client:     &http.Client{Timeout: 15 * time.Second},
+		rate:       rate,
+		burst:      burst,
+		maxRetries: maxRetries,
+		cacheDir:   cacheDir,
+		limiters:   make(map[string]*tokenBucket),
+	}
+}
+
+// Fetch retrieves targetURL, applying rate limiting, retries, and caching.
+// buildRequest is called on the outgoing request to set headers (e.g. the
+// User-Agent); it may be nil.
+func (f *Fetcher) Fetch(ctx context.Context, targetURL string, buildRequest func(*http.Request)) (*FetchResult, error) {
+	cached, cacheKey := f.loadCache(targetURL)
+	if cached != nil && f.isFresh(cached) {
+		return &FetchResult{Body: cached.Body, ContentType: cached.ContentType, FromCache: true}, nil
+	}
+
+	f.limiterFor(hostOf(targetURL)).take()
+
+	var lastErr error
+	for attempt := 0; attempt <= f.maxRetries; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if buildRequest != nil {
+			buildRequest(req)
+		}
+		if cached != nil {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+
+		resp, err := f.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to fetch URL: %w", err)
+			f.backoff(attempt, 0)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			resp.Body.Close()
+			return &FetchResult{Body: cached.Body, ContentType: cached.ContentType, FromCache: true}, nil
+		}
+
+		if isRetryableStatus(resp.StatusCode) && attempt < f.maxRetries {
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+			resp.Body.Close()
+			lastErr = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+			f.backoff(attempt, retryAfter)
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, f.terminalStatusError(resp)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		f.saveCache(cacheKey, resp, body)
+		return &FetchResult{Body: body, ContentType: resp.Header.Get("Content-Type")}, nil
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempts: %w", f.maxRetries+1, lastErr)
+}
+
+// terminalStatusError logs diagnostic information for a non-retryable
+// non-200 response, including anti-bot service detection, and builds the
+// error returned to the caller.
+func (f *Fetcher) terminalStatusError(resp *http.Response) error {
+	antiBotInfo := detectAntiBotService(resp)
+
+	log.Printf("❌ Request blocked - Status: %d", resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if debugMode {
+		log.Printf("📋 Response Headers: %+v", resp.Header)
+		if len(body) > 0 && len(body) < 1000 {
+			log.Printf("📄 Response body: %s", string(body))
+		}
+	}
+
+	errorMsg := fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
+	if antiBotInfo != "" {
+		log.Printf("🛡️  Anti-bot service detected: %s", antiBotInfo)
+		errorMsg += fmt.Sprintf(" (detected: %s)", antiBotInfo)
+	}
+	return fmt.Errorf("%s", errorMsg)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+func (f *Fetcher) backoff(attempt int, retryAfter time.Duration) {
+	wait := retryAfter
+	if wait == 0 {
+		wait = time.Duration(math.Pow(2, float64(attempt))*float64(time.Second)) + time.Duration(rand.Intn(250))*time.Millisecond
+	}
+	time.Sleep(wait)
+}
+
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// tokenBucket is a simple per-host QPS limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	rate   float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{tokens: float64(burst), max: float64(burst), rate: rate, last: time.Now()}
+}
+
+// take blocks until a token is available.
+func (b *tokenBucket) take() {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(b.max, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+func (f *Fetcher) limiterFor(host string) *tokenBucket {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.limiters[host]
+	if !ok {
+		b = newTokenBucket(f.rate, f.burst)
+		f.limiters[host] = b
+	}
+	return b
+}
+
+// cacheEntry is the JSON sidecar stored next to a cached response body; it
+// carries the validators needed for conditional requests and
+// Cache-Control freshness checks.
+type cacheEntry struct {
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	MaxAge       int       `json:"max_age,omitempty"`
+	Expires      time.Time `json:"expires,omitempty"`
+	Body         []byte    `json:"-"`
+}
+
+func cacheKeyFor(targetURL string) string {
+	sum := sha256.Sum256([]byte(normalizeURL(targetURL)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *Fetcher) cachePaths(key string) (metaPath, bodyPath string) {
+	return filepath.Join(f.cacheDir, key+".json"), filepath.Join(f.cacheDir, key+".body")
+}
+
+func (f *Fetcher) loadCache(targetURL string) (*cacheEntry, string) {
+	if f.cacheDir == "" {
+		return nil, ""
+	}
+	key := cacheKeyFor(targetURL)
+	metaPath, bodyPath := f.cachePaths(key)
+
+	metaBytes, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, key
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(metaBytes, &entry); err != nil {
+		return nil, key
+	}
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return nil, key
+	}
+	entry.Body = body
+	return &entry, key
+}
+
+func (f *Fetcher) isFresh(entry *cacheEntry) bool {
+	if entry.MaxAge > 0 {
+		return time.Since(entry.StoredAt) < time.Duration(entry.MaxAge)*time.Second
+	}
+	if !entry.Expires.IsZero() {
+		return time.Now().Before(entry.Expires)
+	}
+	return false
+}
+
+func (f *Fetcher) saveCache(key string, resp *http.Response, body []byte) {
+	if f.cacheDir == "" || key == "" {
+		return
+	}
+	if err := os.MkdirAll(f.cacheDir, 0o755); err != nil {
+		return
+	}
+
+	entry := cacheEntry{
+		ContentType:  resp.Header.Get("Content-Type"),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StoredAt:     time.Now(),
+	}
+	if maxAge, ok := parseMaxAge(resp.Header.Get("Cache-Control")); ok {
+		entry.MaxAge = maxAge
+	}
+	if exp := resp.Header.Get("Expires"); exp != "" {
+		if t, err := http.ParseTime(exp); err == nil {
+			entry.Expires = t
+		}
+	}
+
+	metaPath, bodyPath := f.cachePaths(key)
+	if metaBytes, err := json.Marshal(entry); err == nil {
+		os.WriteFile(metaPath, metaBytes, 0o644)
+	}
+	os.WriteFile(bodyPath, body, 0o644)
+}
+
+func parseMaxAge(cacheControl string) (int, bool) {
+	for _, part := range strings.Split(cacheControl, ",") {
+		name, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(name, "max-age") {
+			if secs, err := strconv.Atoi(val); err == nil {
+				return secs, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -15,7 +14,15 @@ import (
 	"golang.org/x/net/html"
 )
 
-var debugMode bool
+var (
+	debugMode bool
+	fetcher   *Fetcher
+)
+
+// defaultUserAgent is sent on every outgoing request, including robots.txt
+// fetches, so that crawl politeness rules match the identity we actually
+// present to the server.
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
 
 // ScrapedData represents the structured data extracted from a web page
 type ScrapedData struct {
@@ -23,10 +30,27 @@ type ScrapedData struct {
 	Title       string            `json:"title"`
 	Description string            `json:"description"`
 	Keywords    string            `json:"keywords"`
+	Charset     string            `json:"charset"`
 	Links       []Link            `json:"links"`
 	MetaTags    map[string]string `json:"meta_tags"`
 	Images      []string          `json:"images"`
 	Headings    []Heading         `json:"headings"`
+	Article     *Article          `json:"article,omitempty"`
+	JSONLD      []map[string]any  `json:"json_ld,omitempty"`
+	Microdata   []MicrodataItem   `json:"microdata,omitempty"`
+	Entity      map[string]any    `json:"entity,omitempty"`
+}
+
+// Article is the Readability-style extraction of a page's main content,
+// populated only when scrapeHandler is called with ?mode=article.
+type Article struct {
+	Title          string `json:"title"`
+	Byline         string `json:"byline"`
+	Content        string `json:"content"`
+	TextContent    string `json:"text_content"`
+	Length         int    `json:"length"`
+	ExcerptText    string `json:"excerpt"`
+	ReadingTimeSec int    `json:"reading_time_sec"`
 }
 
 // Link represents an extracted hyperlink
@@ -47,15 +71,50 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
+// SelectorSpec describes one named field to extract via a CSS selector or
+// an XPath expression, an accessor for its value, and whether to collect
+// every match instead of just the first.
+type SelectorSpec struct {
+	CSS   string `json:"css,omitempty"`
+	XPath string `json:"xpath,omitempty"`
+	Attr  string `json:"attr,omitempty"` // attribute name, or "text"/"html" (default "text")
+	Multi bool   `json:"multi,omitempty"`
+}
+
+// ScrapeRequest is the JSON body accepted by POST /scrape.
+type ScrapeRequest struct {
+	URL       string                  `json:"url"`
+	Selectors map[string]SelectorSpec `json:"selectors,omitempty"`
+}
+
+// ScrapeResponse extends ScrapedData with the caller's site-specific
+// selector results, keyed by field name.
+type ScrapeResponse struct {
+	*ScrapedData
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
 func main() {
 	// Parse command line flags
+	var cacheDir string
+	var rate float64
+	var burst int
+	var maxRetries int
 	flag.BoolVar(&debugMode, "debug", false, "Enable debug logging")
+	flag.StringVar(&cacheDir, "cache-dir", "", "Directory to cache fetched pages in (disabled if empty)")
+	flag.Float64Var(&rate, "rate", 1, "Max requests per second to a single host")
+	flag.IntVar(&burst, "burst", 2, "Burst size for the per-host rate limiter")
+	flag.IntVar(&maxRetries, "max-retries", 3, "Max retry attempts for failed requests")
 	flag.Parse()
 
+	fetcher = NewFetcher(rate, burst, maxRetries, cacheDir)
+
 	mux := http.NewServeMux()
 
 	// Scraper endpoint
 	mux.HandleFunc("/scrape", scrapeHandler)
+	// Recursive crawler endpoint
+	mux.HandleFunc("/crawl", crawlHandler)
 	// Health check endpoint
 	mux.HandleFunc("/health", healthHandler)
 
@@ -81,14 +140,25 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func scrapeHandler(w http.ResponseWriter, r *http.Request) {
-	// Only accept GET requests
-	if r.Method != http.MethodGet {
-		sendError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+	var targetURL string
+	var selectors map[string]SelectorSpec
+
+	switch r.Method {
+	case http.MethodGet:
+		targetURL = r.URL.Query().Get("url")
+	case http.MethodPost:
+		var body ScrapeRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			sendError(w, http.StatusBadRequest, "invalid_body", "Request body must be valid JSON")
+			return
+		}
+		targetURL = body.URL
+		selectors = body.Selectors
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET and POST methods are allowed")
 		return
 	}
 
-	// Get URL parameter
-	targetURL := r.URL.Query().Get("url")
 	if targetURL == "" {
 		sendError(w, http.StatusBadRequest, "missing_url", "URL parameter is required")
 		return
@@ -101,88 +171,53 @@ func scrapeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Scrape the web page
-	data, err := scrapeWebPage(targetURL)
+	// Scrape the web page, optionally extracting the main article content
+	mode := r.URL.Query().Get("mode")
+	data, rawBody, err := scrapeWebPage(targetURL, mode)
 	if err != nil {
 		log.Printf("Error scraping %s: %v", targetURL, err)
 		sendError(w, http.StatusInternalServerError, "scrape_failed", fmt.Sprintf("Failed to scrape URL: %v", err))
 		return
 	}
 
-	// Send successful response
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(data)
-}
 
-func scrapeWebPage(targetURL string) (*ScrapedData, error) {
-	// Create HTTP client with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+	if len(selectors) == 0 {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(data)
+		return
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	fields, err := extractFields(rawBody, selectors)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		sendError(w, http.StatusBadRequest, "invalid_selectors", err.Error())
+		return
 	}
 
-	// Set realistic browser headers to reduce detection
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	// Note: Don't set Accept-Encoding - Go's http.Client handles compression automatically
-	req.Header.Set("DNT", "1")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ScrapeResponse{ScrapedData: data, Fields: fields})
+}
 
-	client := &http.Client{
-		Timeout: 15 * time.Second,
-	}
+func scrapeWebPage(targetURL, mode string) (*ScrapedData, []byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
 
-	resp, err := client.Do(req)
+	result, err := fetcher.Fetch(ctx, targetURL, setBrowserHeaders)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		// Detect anti-bot services
-		antiBotInfo := detectAntiBotService(resp)
-
-		// Log diagnostic information
-		log.Printf("❌ Request blocked - Status: %d", resp.StatusCode)
-		if debugMode {
-			log.Printf("📋 Response Headers: %+v", resp.Header)
-			if antiBotInfo != "" {
-				log.Printf("🛡️  Anti-bot service detected: %s", antiBotInfo)
-			}
-
-			// Read error response body for more context
-			body, _ := io.ReadAll(resp.Body)
-			if len(body) > 0 && len(body) < 1000 {
-				log.Printf("📄 Response body: %s", string(body))
-			}
-		} else if antiBotInfo != "" {
-			log.Printf("🛡️  Anti-bot service detected: %s", antiBotInfo)
-		}
-
-		errorMsg := fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
-		if antiBotInfo != "" {
-			errorMsg += fmt.Sprintf(" (detected: %s)", antiBotInfo)
-		}
-		return nil, fmt.Errorf(errorMsg)
+		return nil, nil, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 
 	// Check content type
-	contentType := resp.Header.Get("Content-Type")
+	contentType := result.ContentType
 	if !strings.Contains(contentType, "text/html") {
-		return nil, fmt.Errorf("content type is not HTML: %s", contentType)
+		return nil, nil, fmt.Errorf("content type is not HTML: %s", contentType)
 	}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	// Detect the response charset and transcode to UTF-8 so titles,
+	// descriptions, and headings from non-UTF-8 pages aren't mojibake.
+	body, detectedCharset, err := decodeBody(result.Body, contentType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, nil, fmt.Errorf("failed to decode response body: %w", err)
 	}
 
 	// Log response info for debugging
@@ -190,6 +225,7 @@ func scrapeWebPage(targetURL string) (*ScrapedData, error) {
 		log.Printf("✅ Successfully fetched %s", targetURL)
 		log.Printf("📊 Response size: %d bytes", len(body))
 		log.Printf("📄 Content-Type: %s", contentType)
+		log.Printf("🔤 Detected charset: %s", detectedCharset)
 
 		// Show first 500 chars of HTML for debugging
 		preview := string(body)
@@ -202,12 +238,13 @@ func scrapeWebPage(targetURL string) (*ScrapedData, error) {
 	// Parse HTML
 	doc, err := html.Parse(strings.NewReader(string(body)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
 	// Extract data
 	data := &ScrapedData{
 		URL:      targetURL,
+		Charset:  detectedCharset,
 		Links:    []Link{},
 		MetaTags: make(map[string]string),
 		Images:   []string{},
@@ -216,6 +253,11 @@ func scrapeWebPage(targetURL string) (*ScrapedData, error) {
 
 	// Traverse the HTML tree and extract information
 	extractData(doc, data, targetURL)
+	data.Entity = buildEntity(data)
+
+	if mode == "article" {
+		data.Article = extractArticle(doc)
+	}
 
 	// Log extraction results
 	if debugMode {
@@ -223,7 +265,7 @@ func scrapeWebPage(targetURL string) (*ScrapedData, error) {
 			data.Title, len(data.Links), len(data.Images), len(data.Headings), len(data.MetaTags))
 	}
 
-	return data, nil
+	return data, body, nil
 }
 
 func extractData(n *html.Node, data *ScrapedData, baseURL string) {
@@ -239,6 +281,12 @@ func extractData(n *html.Node, data *ScrapedData, baseURL string) {
 			extractImage(n, data, baseURL)
 		case "h1", "h2", "h3", "h4", "h5", "h6":
 			extractHeading(n, data)
+		case "script":
+			extractJSONLD(n, data)
+		}
+
+		if hasAttr(n, "itemscope") && !hasItemscopeAncestor(n) {
+			data.Microdata = append(data.Microdata, parseMicrodataItem(n))
 		}
 	}
 
@@ -275,6 +323,13 @@ func extractMetaTag(n *html.Node, data *ScrapedData) {
 	}
 }
 
+func extractJSONLD(n *html.Node, data *ScrapedData) {
+	if attrVal(n, "type") != "application/ld+json" {
+		return
+	}
+	data.JSONLD = append(data.JSONLD, parseJSONLD(getTextContent(n))...)
+}
+
 func extractLink(n *html.Node, data *ScrapedData, baseURL string) {
 	var href string
 	for _, attr := range n.Attr {
@@ -350,6 +405,19 @@ func getTextContent(n *html.Node) string {
 	return text
 }
 
+// setBrowserHeaders sets realistic browser headers on req to reduce
+// detection. It is also the identity the Fetcher presents when fetching
+// robots.txt, so crawl politeness rules match what we actually send.
+func setBrowserHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", defaultUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	// Note: Don't set Accept-Encoding - Go's http.Client handles compression automatically
+	req.Header.Set("DNT", "1")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+}
+
 func resolveURL(baseURL, href string) string {
 	base, err := url.Parse(baseURL)
 	if err != nil {
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	positiveHint = regexp.MustCompile(`(?i)article|content|post|entry|main|body`)
+	negativeHint = regexp.MustCompile(`(?i)comment|sidebar|footer|nav|share|related|widget|advert`)
+	blockTags    = map[string]bool{"p": true, "pre": true, "article": true, "section": true, "div": true}
+	stripTags    = map[string]bool{"script": true, "style": true, "nav": true, "aside": true, "form": true, "noscript": true}
+)
+
+// candidateScore accumulates a Readability-style relevance score for one
+// block-level node as the tree is walked and as its children's scores are
+// propagated up to their parent and grandparent.
+type candidateScore struct {
+	node  *html.Node
+	score float64
+}
+
+// extractArticle runs a Readability-style scorer over doc and returns the
+// highest-scoring section of the page as a cleaned Article. doc is mutated
+// in place to strip non-content elements, so any other extraction from it
+// must happen first.
+func extractArticle(doc *html.Node) *Article {
+	stripNodes(doc)
+
+	scores := map[*html.Node]*candidateScore{}
+	scoreCandidates(doc, scores)
+
+	best := topCandidate(scores)
+	if best == nil {
+		return fallbackArticle(doc)
+	}
+
+	text := strings.TrimSpace(getTextContent(best.node))
+	return &Article{
+		Title:          pageTitle(doc),
+		Byline:         findByline(doc),
+		Content:        renderHTML(best.node),
+		TextContent:    text,
+		Length:         len(text),
+		ExcerptText:    excerpt(text, 280),
+		ReadingTimeSec: readingTimeSeconds(text),
+	}
+}
+
+// stripNodes removes script/style/nav/aside/form/noscript elements from
+// the tree so they can't contribute to scoring or leak into the content.
+func stripNodes(n *html.Node) {
+	var next *html.Node
+	for c := n.FirstChild; c != nil; c = next {
+		next = c.NextSibling
+		if c.Type == html.ElementNode && stripTags[c.Data] {
+			n.RemoveChild(c)
+			continue
+		}
+		stripNodes(c)
+	}
+}
+
+// scoreCandidates walks the tree, scoring eligible block-level nodes by
+// text length, comma count, and class/id hints, then propagates a
+// fraction of each score to the node's parent and grandparent.
+func scoreCandidates(n *html.Node, scores map[*html.Node]*candidateScore) {
+	if n.Type == html.ElementNode && blockTags[n.Data] {
+		text := strings.TrimSpace(getTextContent(n))
+		if len(text) >= 25 {
+			score := float64(len(text)) / 100
+			score += float64(strings.Count(text, ",")) * 0.5
+			score += classIDBonus(n)
+
+			addScore(scores, n, score)
+			if p := n.Parent; p != nil {
+				addScore(scores, p, score/2)
+				if gp := p.Parent; gp != nil {
+					addScore(scores, gp, score/4)
+				}
+			}
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		scoreCandidates(c, scores)
+	}
+}
+
+func addScore(scores map[*html.Node]*candidateScore, n *html.Node, delta float64) {
+	cs, ok := scores[n]
+	if !ok {
+		cs = &candidateScore{node: n}
+		scores[n] = cs
+	}
+	cs.score += delta
+}
+
+func classIDBonus(n *html.Node) float64 {
+	var bonus float64
+	for _, attr := range n.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if positiveHint.MatchString(attr.Val) {
+			bonus += 25
+		}
+		if negativeHint.MatchString(attr.Val) {
+			bonus -= 25
+		}
+	}
+	return bonus
+}
+
+// topCandidate returns the highest-scoring node, excluding any whose
+// link-density (link text / total text) exceeds 0.5 — such nodes are
+// usually navigation or related-link blocks, not the article body.
+func topCandidate(scores map[*html.Node]*candidateScore) *candidateScore {
+	var best *candidateScore
+	for _, cs := range scores {
+		if linkDensity(cs.node) > 0.5 {
+			continue
+		}
+		if best == nil || cs.score > best.score {
+			best = cs
+		}
+	}
+	return best
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len(getTextContent(n))
+	if total == 0 {
+		return 0
+	}
+
+	var linkLen int
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.Data == "a" {
+			linkLen += len(getTextContent(c))
+		}
+		for k := c.FirstChild; k != nil; k = k.NextSibling {
+			walk(k)
+		}
+	}
+	walk(n)
+
+	return float64(linkLen) / float64(total)
+}
+
+func renderHTML(n *html.Node) string {
+	var buf strings.Builder
+	if err := html.Render(&buf, n); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func pageTitle(doc *html.Node) string {
+	var title string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if title != "" {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "title" {
+			title = strings.TrimSpace(getTextContent(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return title
+}
+
+// findByline looks for a meta author tag or a rel="author" element,
+// which covers the common ways pages mark up their byline.
+func findByline(doc *html.Node) string {
+	var byline string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if byline != "" {
+			return
+		}
+		if n.Type == html.ElementNode {
+			if n.Data == "meta" {
+				var name, content string
+				for _, attr := range n.Attr {
+					switch attr.Key {
+					case "name", "property":
+						name = attr.Val
+					case "content":
+						content = attr.Val
+					}
+				}
+				if strings.EqualFold(name, "author") || strings.EqualFold(name, "article:author") {
+					byline = content
+					return
+				}
+			}
+			for _, attr := range n.Attr {
+				if attr.Key == "rel" && attr.Val == "author" {
+					byline = strings.TrimSpace(getTextContent(n))
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return byline
+}
+
+func excerpt(text string, max int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= max {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:max])) + "…"
+}
+
+func readingTimeSeconds(text string) int {
+	const wordsPerMinute = 200
+	words := len(strings.Fields(text))
+	return words * 60 / wordsPerMinute
+}
+
+// fallbackArticle is used when no candidate scored high enough to trust,
+// e.g. very short or heavily link-dense pages.
+func fallbackArticle(doc *html.Node) *Article {
+	text := strings.TrimSpace(getTextContent(doc))
+	return &Article{
+		Title:          pageTitle(doc),
+		TextContent:    text,
+		Length:         len(text),
+		ExcerptText:    excerpt(text, 280),
+		ReadingTimeSec: readingTimeSeconds(text),
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsRules holds the Disallow/Allow rules and crawl-delay parsed from a
+// single host's robots.txt, scoped to one User-Agent group.
+type robotsRules struct {
+	disallow   []string
+	allow      []string
+	crawlDelay time.Duration
+}
+
+// robotsCache fetches and caches one robots.txt per host so a crawl doesn't
+// refetch it for every page on that host.
+type robotsCache struct {
+	mu     sync.Mutex
+	rules  map[string]*robotsRules
+	client *http.Client
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{
+		rules:  make(map[string]*robotsRules),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// allowed reports whether userAgent may fetch rawURL per the rules in
+// rawURL's host's robots.txt, fetched over scheme. A missing or
+// unfetchable robots.txt allows everything.
+func (c *robotsCache) allowed(host, scheme, rawURL, userAgent string) bool {
+	rules := c.rulesFor(host, scheme, userAgent)
+	if rules == nil {
+		return true
+	}
+	return rules.permits(pathOf(rawURL))
+}
+
+func (c *robotsCache) crawlDelay(host, scheme, userAgent string) time.Duration {
+	if rules := c.rulesFor(host, scheme, userAgent); rules != nil {
+		return rules.crawlDelay
+	}
+	return 0
+}
+
+func (c *robotsCache) rulesFor(host, scheme, userAgent string) *robotsRules {
+	c.mu.Lock()
+	if rules, ok := c.rules[host]; ok {
+		c.mu.Unlock()
+		return rules
+	}
+	c.mu.Unlock()
+
+	rules := c.fetch(host, scheme, userAgent)
+
+	c.mu.Lock()
+	c.rules[host] = rules
+	c.mu.Unlock()
+	return rules
+}
+
+// fetch requests host's robots.txt over scheme (the scheme of the page
+// actually being crawled), falling back to plain http if that fails —
+// a scheme mismatch (e.g. assuming https for an http-only host) must not
+// be mistaken for "no robots.txt" and silently allow everything.
+func (c *robotsCache) fetch(host, scheme, userAgent string) *robotsRules {
+	if rules := c.fetchOverScheme(host, scheme, userAgent); rules != nil {
+		return rules
+	}
+	if scheme != "http" {
+		return c.fetchOverScheme(host, "http", userAgent)
+	}
+	return nil
+}
+
+func (c *robotsCache) fetchOverScheme(host, scheme, userAgent string) *robotsRules {
+	req, err := http.NewRequest(http.MethodGet, scheme+"://"+host+"/robots.txt", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return parseRobotsTxt(resp.Body, userAgent)
+}
+
+// parseRobotsTxt extracts the Disallow/Allow/Crawl-delay directives that
+// apply to userAgent, preferring a group that names it explicitly and
+// falling back to the wildcard "*" group otherwise.
+func parseRobotsTxt(r io.Reader, userAgent string) *robotsRules {
+	scanner := bufio.NewScanner(r)
+
+	var wildcard, specific robotsRules
+	var current *robotsRules
+	sawSpecific := false
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "user-agent":
+			switch {
+			case val == "*":
+				current = &wildcard
+			case strings.Contains(strings.ToLower(userAgent), strings.ToLower(val)):
+				current = &specific
+				sawSpecific = true
+			default:
+				current = nil
+			}
+		case "disallow":
+			if current != nil && val != "" {
+				current.disallow = append(current.disallow, val)
+			}
+		case "allow":
+			if current != nil && val != "" {
+				current.allow = append(current.allow, val)
+			}
+		case "crawl-delay":
+			if current != nil {
+				if secs, err := strconv.ParseFloat(val, 64); err == nil {
+					current.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if sawSpecific {
+		return &specific
+	}
+	return &wildcard
+}
+
+// permits reports whether path is allowed under these rules, using the
+// longest-matching-prefix-wins precedence most crawlers follow.
+func (r *robotsRules) permits(path string) bool {
+	matchLen := -1
+	allow := true
+
+	for _, p := range r.disallow {
+		if strings.HasPrefix(path, p) && len(p) > matchLen {
+			matchLen = len(p)
+			allow = false
+		}
+	}
+	for _, p := range r.allow {
+		if strings.HasPrefix(path, p) && len(p) > matchLen {
+			matchLen = len(p)
+			allow = true
+		}
+	}
+	return allow
+}
+
+func pathOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// extractFields compiles and runs each named selector in specs against
+// rawHTML and returns the extracted values keyed by field name. CSS and
+// XPath selectors each parse the document at most once, lazily, the
+// first time one of their kind is needed.
+func extractFields(rawHTML []byte, specs map[string]SelectorSpec) (map[string]any, error) {
+	fields := make(map[string]any, len(specs))
+
+	var cssDoc *goquery.Document
+	var xpathDoc *html.Node
+
+	for name, spec := range specs {
+		switch {
+		case spec.CSS != "":
+			if cssDoc == nil {
+				doc, err := goquery.NewDocumentFromReader(bytes.NewReader(rawHTML))
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse document for CSS selectors: %w", err)
+				}
+				cssDoc = doc
+			}
+			val, err := extractCSS(cssDoc, spec)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: invalid css selector: %w", name, err)
+			}
+			fields[name] = val
+
+		case spec.XPath != "":
+			if xpathDoc == nil {
+				doc, err := htmlquery.Parse(bytes.NewReader(rawHTML))
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse document for XPath selectors: %w", err)
+				}
+				xpathDoc = doc
+			}
+			val, err := extractXPath(xpathDoc, spec)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: invalid xpath: %w", name, err)
+			}
+			fields[name] = val
+
+		default:
+			return nil, fmt.Errorf("field %q: must declare either css or xpath", name)
+		}
+	}
+
+	return fields, nil
+}
+
+func extractCSS(doc *goquery.Document, spec SelectorSpec) (any, error) {
+	if _, err := cascadia.Compile(spec.CSS); err != nil {
+		return nil, err
+	}
+	sel := doc.Find(spec.CSS)
+
+	if spec.Multi {
+		values := make([]string, 0, sel.Length())
+		sel.Each(func(_ int, s *goquery.Selection) {
+			values = append(values, cssValue(s, spec.Attr))
+		})
+		return values, nil
+	}
+
+	if sel.Length() == 0 {
+		return nil, nil
+	}
+	return cssValue(sel.First(), spec.Attr), nil
+}
+
+func cssValue(s *goquery.Selection, attr string) string {
+	switch attr {
+	case "", "text":
+		return strings.TrimSpace(s.Text())
+	case "html":
+		h, _ := s.Html()
+		return h
+	default:
+		v, _ := s.Attr(attr)
+		return v
+	}
+}
+
+func extractXPath(doc *html.Node, spec SelectorSpec) (any, error) {
+	if spec.Multi {
+		nodes, err := htmlquery.QueryAll(doc, spec.XPath)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, 0, len(nodes))
+		for _, n := range nodes {
+			values = append(values, xpathValue(n, spec.Attr))
+		}
+		return values, nil
+	}
+
+	n, err := htmlquery.Query(doc, spec.XPath)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, nil
+	}
+	return xpathValue(n, spec.Attr), nil
+}
+
+func xpathValue(n *html.Node, attr string) string {
+	switch attr {
+	case "", "text":
+		return strings.TrimSpace(htmlquery.InnerText(n))
+	case "html":
+		return htmlquery.OutputHTML(n, true)
+	default:
+		return htmlquery.SelectAttr(n, attr)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// MicrodataItem represents one itemscope element: its itemtype and the
+// itemprop values found within it (which may themselves be nested
+// MicrodataItems for nested itemscopes).
+type MicrodataItem struct {
+	Type  string         `json:"type,omitempty"`
+	Props map[string]any `json:"props"`
+}
+
+// trailingCommaRe strips trailing commas before closing braces/brackets so
+// common but technically-invalid JSON-LD blocks still parse.
+var trailingCommaRe = regexp.MustCompile(`,\s*([}\]])`)
+
+// parseJSONLD parses the text content of a <script type="application/ld+json">
+// block, tolerating arrays, @graph wrappers, and trailing commas. It
+// returns one map per top-level entity found.
+func parseJSONLD(raw string) []map[string]any {
+	cleaned := trailingCommaRe.ReplaceAllString(raw, "$1")
+
+	var parsed any
+	if err := json.Unmarshal([]byte(cleaned), &parsed); err != nil {
+		return nil
+	}
+
+	var out []map[string]any
+	var collect func(any)
+	collect = func(v any) {
+		switch val := v.(type) {
+		case map[string]any:
+			if graph, ok := val["@graph"]; ok {
+				collect(graph)
+				return
+			}
+			out = append(out, val)
+		case []any:
+			for _, item := range val {
+				collect(item)
+			}
+		}
+	}
+	collect(parsed)
+	return out
+}
+
+// parseMicrodataItem walks n's subtree collecting itemprop values into a
+// MicrodataItem, recursing into nested itemscopes as nested items.
+func parseMicrodataItem(n *html.Node) MicrodataItem {
+	item := MicrodataItem{Type: attrVal(n, "itemtype"), Props: map[string]any{}}
+
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode {
+			if prop := attrVal(c, "itemprop"); prop != "" {
+				if hasAttr(c, "itemscope") {
+					addMicrodataProp(item.Props, prop, parseMicrodataItem(c))
+					return
+				}
+				addMicrodataProp(item.Props, prop, microdataValue(c))
+			} else if hasAttr(c, "itemscope") {
+				return // a nested itemscope with no itemprop starts its own top-level item
+			}
+		}
+		for k := c.FirstChild; k != nil; k = k.NextSibling {
+			walk(k)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	return item
+}
+
+func addMicrodataProp(props map[string]any, key string, val any) {
+	if existing, ok := props[key]; ok {
+		if list, ok := existing.([]any); ok {
+			props[key] = append(list, val)
+		} else {
+			props[key] = []any{existing, val}
+		}
+		return
+	}
+	props[key] = val
+}
+
+// microdataValue extracts an itemprop element's value per the HTML
+// microdata spec: the URL attribute for links/media, datetime for <time>,
+// content for <meta>, and text content otherwise.
+func microdataValue(n *html.Node) string {
+	switch n.Data {
+	case "a", "link", "area":
+		return attrVal(n, "href")
+	case "img", "audio", "video", "source", "track", "embed", "iframe":
+		return attrVal(n, "src")
+	case "time":
+		if dt := attrVal(n, "datetime"); dt != "" {
+			return dt
+		}
+	case "meta":
+		return attrVal(n, "content")
+	}
+	return strings.TrimSpace(getTextContent(n))
+}
+
+func attrVal(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasItemscopeAncestor(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && hasAttr(p, "itemscope") {
+			return true
+		}
+	}
+	return false
+}
+
+// wellKnownEntityKeys are the canonical fields buildEntity folds JSON-LD,
+// Microdata, and OpenGraph/meta values into.
+var wellKnownEntityKeys = []string{"name", "description", "image", "author", "datePublished", "price", "sku"}
+
+// buildEntity normalizes JSON-LD, Microdata, and OpenGraph/meta tags into a
+// single canonical shape, preferring JSON-LD over Microdata over
+// OpenGraph over plain <meta name> tags when a field appears in more than
+// one source.
+func buildEntity(data *ScrapedData) map[string]any {
+	entity := map[string]any{}
+
+	applyMetaTags(entity, data.MetaTags)
+	applyOpenGraph(entity, data.MetaTags)
+	for _, item := range data.Microdata {
+		applyMicrodata(entity, item)
+	}
+	for _, item := range data.JSONLD {
+		applyJSONLD(entity, item)
+	}
+
+	if len(entity) == 0 {
+		return nil
+	}
+	return entity
+}
+
+func applyMetaTags(entity map[string]any, meta map[string]string) {
+	setIfPresent(entity, "description", meta["description"])
+	setIfPresent(entity, "author", meta["author"])
+}
+
+func applyOpenGraph(entity map[string]any, meta map[string]string) {
+	setIfPresent(entity, "type", meta["og:type"])
+	setIfPresent(entity, "name", firstNonEmpty(meta["og:title"], meta["twitter:title"]))
+	setIfPresent(entity, "description", firstNonEmpty(meta["og:description"], meta["twitter:description"]))
+	setIfPresent(entity, "image", firstNonEmpty(meta["og:image"], meta["twitter:image"]))
+}
+
+func applyMicrodata(entity map[string]any, item MicrodataItem) {
+	setIfPresent(entity, "type", lastPathSegment(item.Type))
+	for _, key := range wellKnownEntityKeys {
+		if v, ok := item.Props[key]; ok && !isEmptyValue(v) {
+			entity[key] = v
+		}
+	}
+}
+
+func applyJSONLD(entity map[string]any, obj map[string]any) {
+	if t, ok := obj["@type"].(string); ok {
+		entity["type"] = t
+	}
+	for _, key := range wellKnownEntityKeys {
+		if v, ok := obj[key]; ok && !isEmptyValue(v) {
+			entity[key] = v
+		}
+	}
+	if offers, ok := obj["offers"].(map[string]any); ok {
+		if price, ok := offers["price"]; ok && !isEmptyValue(price) {
+			entity["price"] = price
+		}
+	}
+}
+
+func setIfPresent(entity map[string]any, key, val string) {
+	if val != "" {
+		entity[key] = val
+	}
+}
+
+// isEmptyValue reports whether v is a JSON-LD/Microdata value that carries
+// no real information (nil, an empty string, or an empty slice/map), so a
+// lower-precedence source can't blank out a field a higher-precedence
+// source already populated.
+func isEmptyValue(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case []any:
+		return len(val) == 0
+	case map[string]any:
+		return len(val) == 0
+	default:
+		return false
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func lastPathSegment(s string) string {
+	if idx := strings.LastIndex(s, "/"); idx != -1 {
+		return s[idx+1:]
+	}
+	return s
+}